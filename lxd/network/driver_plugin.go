@@ -0,0 +1,158 @@
+package network
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// DefaultPluginSocketDir is scanned at daemon startup for out-of-tree network driver sockets.
+const DefaultPluginSocketDir = "/var/lib/lxd/network-plugins"
+
+// Driver is implemented by an out-of-tree network type (e.g. Calico, Weave, a custom SDN),
+// reached over a Unix socket RPC connection rather than compiled into the daemon.
+type Driver interface {
+	// CreateNetwork creates the named network with the given config.
+	CreateNetwork(name string, config map[string]string) error
+
+	// DeleteNetwork deletes the named network.
+	DeleteNetwork(name string) error
+
+	// Join attaches a container NIC (devName) to the named network.
+	Join(name string, devName string) error
+
+	// Leave detaches a container NIC (devName) from the named network.
+	Leave(name string, devName string) error
+
+	// EndpointOperInfo returns the state used by networkGetState for the named network.
+	EndpointOperInfo(name string) (api.NetworkState, error)
+
+	// Interfaces returns the host interface names this driver currently manages.
+	Interfaces() ([]string, error)
+}
+
+// rpcDriver is a Driver backed by a net/rpc connection to a plugin's Unix socket.
+type rpcDriver struct {
+	client *rpc.Client
+}
+
+func (d *rpcDriver) CreateNetwork(name string, config map[string]string) error {
+	args := struct {
+		Name   string
+		Config map[string]string
+	}{name, config}
+
+	return d.client.Call("Driver.CreateNetwork", args, &struct{}{})
+}
+
+func (d *rpcDriver) DeleteNetwork(name string) error {
+	return d.client.Call("Driver.DeleteNetwork", name, &struct{}{})
+}
+
+func (d *rpcDriver) Join(name string, devName string) error {
+	args := struct{ Name, DevName string }{name, devName}
+	return d.client.Call("Driver.Join", args, &struct{}{})
+}
+
+func (d *rpcDriver) Leave(name string, devName string) error {
+	args := struct{ Name, DevName string }{name, devName}
+	return d.client.Call("Driver.Leave", args, &struct{}{})
+}
+
+func (d *rpcDriver) EndpointOperInfo(name string) (api.NetworkState, error) {
+	state := api.NetworkState{}
+	err := d.client.Call("Driver.EndpointOperInfo", name, &state)
+	return state, err
+}
+
+func (d *rpcDriver) Interfaces() ([]string, error) {
+	ifaces := []string{}
+	err := d.client.Call("Driver.Interfaces", struct{}{}, &ifaces)
+	return ifaces, err
+}
+
+// pluginRegistry tracks the out-of-tree network driver plugins discovered at startup, keyed by
+// the network "type" they serve (e.g. "calico").
+type pluginRegistry struct {
+	mu      sync.RWMutex
+	drivers map[string]Driver
+}
+
+// DriverPlugins is the process-wide registry of out-of-tree network driver plugins.
+var DriverPlugins = &pluginRegistry{drivers: map[string]Driver{}}
+
+// Get returns the registered driver for typeName, or an error if none is registered.
+func (r *pluginRegistry) Get(typeName string) (Driver, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	driver, ok := r.drivers[typeName]
+	if !ok {
+		return nil, fmt.Errorf("No network driver plugin registered for type %q", typeName)
+	}
+
+	return driver, nil
+}
+
+// Register adds (or replaces) the driver used to serve typeName.
+func (r *pluginRegistry) Register(typeName string, driver Driver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.drivers[typeName] = driver
+}
+
+// Interfaces returns the union of host interfaces reported by all registered driver plugins.
+func (r *pluginRegistry) Interfaces() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ifaces := []string{}
+	for _, driver := range r.drivers {
+		driverIfaces, err := driver.Interfaces()
+		if err != nil {
+			continue
+		}
+
+		ifaces = append(ifaces, driverIfaces...)
+	}
+
+	return ifaces
+}
+
+// Discover scans dir for Unix-socket network driver plugins and registers one Driver per socket,
+// named after the socket's base filename with the .sock suffix stripped (e.g. calico.sock
+// registers the "calico" network type). Called at daemon startup; a missing dir is not an error.
+func (r *pluginRegistry) Discover(dir string) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".sock" {
+			continue
+		}
+
+		typeName := strings.TrimSuffix(entry.Name(), ".sock")
+
+		client, err := rpc.Dial("unix", filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		r.Register(typeName, &rpcDriver{client: client})
+	}
+
+	return nil
+}
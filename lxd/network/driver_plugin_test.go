@@ -0,0 +1,179 @@
+package network
+
+import (
+	"io/ioutil"
+	"net"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fakeDriverService struct {
+	interfaces []string
+}
+
+func (s *fakeDriverService) CreateNetwork(args struct {
+	Name   string
+	Config map[string]string
+}, reply *struct{}) error {
+	return nil
+}
+
+func (s *fakeDriverService) Interfaces(args struct{}, reply *[]string) error {
+	*reply = s.interfaces
+	return nil
+}
+
+// serveFakeDriver starts a net/rpc server for fakeDriverService on a Unix socket at path, and
+// returns a func to stop it. The service is registered as "Driver" so calls like
+// "Driver.Interfaces" made by rpcDriver resolve to it.
+func serveFakeDriver(t *testing.T, path string, svc *fakeDriverService) func() {
+	t.Helper()
+
+	server := rpc.NewServer()
+	err := server.RegisterName("Driver", svc)
+	if err != nil {
+		t.Fatalf("RegisterName failed: %v", err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("net.Listen failed: %v", err)
+	}
+
+	go server.Accept(listener)
+
+	return func() { listener.Close() }
+}
+
+func TestPluginRegistryRegisterAndGet(t *testing.T) {
+	r := &pluginRegistry{drivers: map[string]Driver{}}
+
+	_, err := r.Get("calico")
+	if err == nil {
+		t.Fatal("expected an error for an unregistered type")
+	}
+
+	driver := &rpcDriver{}
+	r.Register("calico", driver)
+
+	got, err := r.Get("calico")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != driver {
+		t.Errorf("Get returned %v, want %v", got, driver)
+	}
+}
+
+func TestPluginRegistryInterfaces(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lxd-network-plugin-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	sockPath := filepath.Join(dir, "calico.sock")
+	stop := serveFakeDriver(t, sockPath, &fakeDriverService{interfaces: []string{"cali0", "cali1"}})
+	defer stop()
+
+	r := &pluginRegistry{drivers: map[string]Driver{}}
+	err = r.Discover(dir)
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+
+	driver, err := r.Get("calico")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	ifaces, err := driver.Interfaces()
+	if err != nil {
+		t.Fatalf("Interfaces failed: %v", err)
+	}
+
+	want := []string{"cali0", "cali1"}
+	if len(ifaces) != len(want) {
+		t.Fatalf("Interfaces = %v, want %v", ifaces, want)
+	}
+
+	for i := range want {
+		if ifaces[i] != want[i] {
+			t.Errorf("Interfaces[%d] = %q, want %q", i, ifaces[i], want[i])
+		}
+	}
+
+	union := r.Interfaces()
+	if len(union) != 2 {
+		t.Errorf("registry Interfaces() = %v, want 2 entries", union)
+	}
+}
+
+func TestPluginRegistryDiscover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lxd-network-plugin-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A non-socket file in the directory should be ignored.
+	err = ioutil.WriteFile(filepath.Join(dir, "README"), []byte("not a plugin"), 0644)
+	if err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	sockPath := filepath.Join(dir, "weave.sock")
+	stop := serveFakeDriver(t, sockPath, &fakeDriverService{})
+	defer stop()
+
+	r := &pluginRegistry{drivers: map[string]Driver{}}
+	err = r.Discover(dir)
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+
+	if _, err := r.Get("weave"); err != nil {
+		t.Errorf("expected weave to be registered, got error: %v", err)
+	}
+
+	if _, err := r.Get("README"); err == nil {
+		t.Error("did not expect README to be registered as a driver type")
+	}
+}
+
+func TestPluginRegistryDiscoverMissingDir(t *testing.T) {
+	r := &pluginRegistry{drivers: map[string]Driver{}}
+
+	err := r.Discover(filepath.Join(os.TempDir(), "lxd-network-plugin-test-does-not-exist"))
+	if err != nil {
+		t.Fatalf("Discover on a missing dir should not error, got: %v", err)
+	}
+}
+
+func TestRpcDriverCreateNetwork(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lxd-network-plugin-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	sockPath := filepath.Join(dir, "calico.sock")
+	stop := serveFakeDriver(t, sockPath, &fakeDriverService{})
+	defer stop()
+
+	client, err := rpc.Dial("unix", sockPath)
+	if err != nil {
+		t.Fatalf("rpc.Dial failed: %v", err)
+	}
+	defer client.Close()
+
+	driver := &rpcDriver{client: client}
+
+	err = driver.CreateNetwork("test0", map[string]string{"foo": "bar"})
+	if err != nil {
+		t.Fatalf("CreateNetwork failed: %v", err)
+	}
+}
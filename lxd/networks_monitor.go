@@ -0,0 +1,145 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/lxc/lxd/lxd/resources/network"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// networkStateChangeDebounce is the window used to coalesce a burst of netlink link/address
+// updates for the same interface into a single "network-state-changed" lifecycle event.
+const networkStateChangeDebounce = 250 * time.Millisecond
+
+// networkMonitorLinkState subscribes to netlink RTM_NEWLINK/RTM_DELLINK/RTM_NEWADDR updates and,
+// for every interface whose name is a network managed by LXD, emits a debounced "network-state-changed" lifecycle event carrying the same api.NetworkState payload
+// as GET /1.0/networks/<name>/state. This lets consumers react to carrier changes, MTU changes
+// or address additions without polling. Physical devices that are only referenced by an instance
+// NIC, and are not themselves managed networks, produce no events. The returned stop function tears down the subscriptions
+// and must be called on daemon shutdown.
+func networkMonitorLinkState(s *state.State) (func(), error) {
+	linkUpdates := make(chan netlink.LinkUpdate)
+	linkDone := make(chan struct{})
+	err := netlink.LinkSubscribe(linkUpdates, linkDone)
+	if err != nil {
+		return nil, err
+	}
+
+	addrUpdates := make(chan netlink.AddrUpdate)
+	addrDone := make(chan struct{})
+	err = netlink.AddrSubscribe(addrUpdates, addrDone)
+	if err != nil {
+		close(linkDone)
+		return nil, err
+	}
+
+	// stopped is closed once the loop below has exited, stopped every pending debounce timer
+	// that hadn't yet fired, and waited for any that had already fired to finish running. This
+	// way stop() can't return (and let the caller move on) while an emit() is still in flight.
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		// pending is only ever read or written from this goroutine, so that stop() doesn't
+		// need to synchronize with it. inFlight tracks debounce callbacks that are scheduled
+		// or running, since each fires on its own goroutine via time.AfterFunc rather than on
+		// this one.
+		pending := map[string]*time.Timer{}
+		var inFlight sync.WaitGroup
+		defer func() {
+			for _, timer := range pending {
+				if timer.Stop() {
+					inFlight.Done()
+				}
+			}
+
+			inFlight.Wait()
+		}()
+
+		emit := func(name string) {
+			defer inFlight.Done()
+
+			managed, err := networkIsMonitored(s, name)
+			if err != nil || !managed {
+				return
+			}
+
+			netState, err := network.GetNetworkState(name)
+			if err != nil {
+				logger.Warn("Failed to read network state", log15.Ctx{"interface": name, "err": err})
+				return
+			}
+
+			s.Events.SendLifecycle("", "network-state-changed", map[string]interface{}{
+				"name":  name,
+				"state": netState,
+			})
+		}
+
+		schedule := func(name string) {
+			if timer, ok := pending[name]; ok {
+				if timer.Stop() {
+					inFlight.Done()
+				}
+			}
+
+			inFlight.Add(1)
+			pending[name] = time.AfterFunc(networkStateChangeDebounce, func() { emit(name) })
+		}
+
+		links, err := netlink.LinkList()
+		linkNames := map[int]string{}
+		if err == nil {
+			for _, link := range links {
+				linkNames[link.Attrs().Index] = link.Attrs().Name
+			}
+		}
+
+		for {
+			select {
+			case update, ok := <-linkUpdates:
+				if !ok {
+					return
+				}
+
+				linkNames[int(update.Index)] = update.Link.Attrs().Name
+				schedule(update.Link.Attrs().Name)
+			case update, ok := <-addrUpdates:
+				if !ok {
+					return
+				}
+
+				if name, found := linkNames[update.LinkIndex]; found {
+					schedule(name)
+				}
+			}
+		}
+	}()
+
+	stop := func() {
+		close(linkDone)
+		close(addrDone)
+		<-stopped
+	}
+
+	return stop, nil
+}
+
+// networkIsMonitored returns true if name is a network managed by LXD. Host devices referenced by
+// an instance NIC are not checked, so a physical device that is not a managed network returns
+// false.
+func networkIsMonitored(s *state.State, name string) (bool, error) {
+	networks, err := s.Cluster.GetNetworks()
+	if err != nil {
+		return false, err
+	}
+
+	return shared.StringInSlice(name, networks), nil
+}
@@ -2,9 +2,7 @@ package main
 
 import (
 	"fmt"
-	"io/ioutil"
 	"net"
-	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -12,23 +10,36 @@ import (
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/network"
+	resourcenetwork "github.com/lxc/lxd/lxd/resources/network"
 	"github.com/lxc/lxd/lxd/state"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 )
 
-func readUint(path string) (uint64, error) {
-	content, err := ioutil.ReadFile(path)
-	if err != nil {
-		return 0, err
-	}
+// networkBuiltinTypes are the network types implemented directly in lxd/network. Any other
+// type is expected to be served by a driver registered in network.DriverPlugins.
+var networkBuiltinTypes = []string{"bridge", "macvlan", "sriov", "ovn"}
 
-	value, err := strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64)
+// networkGetState returns the current state of netIf.
+//
+// Deprecated: the body of this function now lives in resources/network.GetNetworkState, which
+// this wraps. networks.go, nic_bridged.go and nic_ovn.go aren't part of this tree slice to
+// migrate directly, so this compatibility wrapper is kept under the old name and signature to
+// avoid breaking any such call sites until they're moved over to the new package.
+func networkGetState(netIf net.Interface) api.NetworkState {
+	state, err := resourcenetwork.GetNetworkState(netIf.Name)
 	if err != nil {
-		return 0, err
+		return api.NetworkState{}
 	}
 
-	return value, nil
+	return *state
+}
+
+// networkLoadDriverPlugins discovers out-of-tree network driver plugins under
+// network.DefaultPluginSocketDir. It should be called once during daemon startup; this tree
+// slice doesn't include the startup sequence itself (main.go/daemon.go), so nothing calls it yet.
+func networkLoadDriverPlugins() error {
+	return network.DriverPlugins.Discover(network.DefaultPluginSocketDir)
 }
 
 func networkAutoAttach(cluster *db.Cluster, devName string) error {
@@ -38,6 +49,15 @@ func networkAutoAttach(cluster *db.Cluster, devName string) error {
 		return nil
 	}
 
+	if !shared.StringInSlice(dbInfo.Type, networkBuiltinTypes) {
+		driver, err := network.DriverPlugins.Get(dbInfo.Type)
+		if err != nil {
+			return err
+		}
+
+		return driver.Join(dbInfo.Name, devName)
+	}
+
 	return network.AttachInterface(dbInfo.Name, devName)
 }
 
@@ -64,6 +84,13 @@ func networkGetInterfaces(cluster *db.Cluster) ([]string, error) {
 		}
 	}
 
+	// Include interfaces reported by registered out-of-tree network driver plugins.
+	for _, iface := range network.DriverPlugins.Interfaces() {
+		if !shared.StringInSlice(iface, networks) {
+			networks = append(networks, iface)
+		}
+	}
+
 	return networks, nil
 }
 
@@ -108,49 +135,82 @@ func networkValidPort(value string) error {
 	return nil
 }
 
+// networkValidAddressCIDRV6 validates value as a comma-separated list of one or more IPv6
+// subnets in CIDR notation, rejecting any that overlap each other.
 func networkValidAddressCIDRV6(value string) error {
 	if value == "" {
 		return nil
 	}
 
-	ip, subnet, err := net.ParseCIDR(value)
-	if err != nil {
-		return err
-	}
+	subnets := []*net.IPNet{}
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
 
-	if ip.To4() != nil {
-		return fmt.Errorf("Not an IPv6 address: %s", value)
-	}
+		ip, subnet, err := net.ParseCIDR(v)
+		if err != nil {
+			return err
+		}
 
-	if ip.String() == subnet.IP.String() {
-		return fmt.Errorf("Not a usable IPv6 address: %s", value)
+		if ip.To4() != nil {
+			return fmt.Errorf("Not an IPv6 address: %s", v)
+		}
+
+		if ip.String() == subnet.IP.String() {
+			return fmt.Errorf("Not a usable IPv6 address: %s", v)
+		}
+
+		subnets = append(subnets, subnet)
 	}
 
-	return nil
+	return networkValidSubnetsNonOverlapping(subnets)
 }
 
+// networkValidAddressCIDRV4 validates value as a comma-separated list of one or more IPv4
+// subnets in CIDR notation, rejecting any that overlap each other.
 func networkValidAddressCIDRV4(value string) error {
 	if value == "" {
 		return nil
 	}
 
-	ip, subnet, err := net.ParseCIDR(value)
-	if err != nil {
-		return err
-	}
+	subnets := []*net.IPNet{}
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+
+		ip, subnet, err := net.ParseCIDR(v)
+		if err != nil {
+			return err
+		}
 
-	if ip.To4() == nil {
-		return fmt.Errorf("Not an IPv4 address: %s", value)
+		if ip.To4() == nil {
+			return fmt.Errorf("Not an IPv4 address: %s", v)
+		}
+
+		if ip.String() == subnet.IP.String() {
+			return fmt.Errorf("Not a usable IPv4 address: %s", v)
+		}
+
+		subnets = append(subnets, subnet)
 	}
 
-	if ip.String() == subnet.IP.String() {
-		return fmt.Errorf("Not a usable IPv4 address: %s", value)
+	return networkValidSubnetsNonOverlapping(subnets)
+}
+
+// networkValidSubnetsNonOverlapping returns an error if any two of the given subnets overlap.
+func networkValidSubnetsNonOverlapping(subnets []*net.IPNet) error {
+	for i, a := range subnets {
+		for _, b := range subnets[i+1:] {
+			if a.Contains(b.IP) || b.Contains(a.IP) {
+				return fmt.Errorf("Subnet %s overlaps with %s", a.String(), b.String())
+			}
+		}
 	}
 
 	return nil
 }
 
-// networkUpdateForkdnsServersTask runs every 30s and refreshes the forkdns servers list.
+// networkUpdateForkdnsServersTask runs every 30s. For fan bridges it refreshes the forkdns
+// servers list, and for overlay bridges it rebuilds the cluster-wide VXLAN FDB mesh, in both
+// cases keyed off the current cluster member list from the heartbeat.
 func networkUpdateForkdnsServersTask(s *state.State, heartbeatData *cluster.APIHeartbeat) error {
 	// Get a list of managed networks
 	networks, err := s.Cluster.GetNonPendingNetworks()
@@ -164,188 +224,80 @@ func networkUpdateForkdnsServersTask(s *state.State, heartbeatData *cluster.APIH
 			return err
 		}
 
-		if n.Config()["bridge.mode"] == "fan" {
+		switch n.Config()["bridge.mode"] {
+		case "fan":
 			err := n.RefreshForkdnsServerAddresses(heartbeatData)
 			if err != nil {
 				return err
 			}
+		case "overlay":
+			err := networkRefreshOverlayFDB(n, heartbeatData)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
-func networkGetState(netIf net.Interface) api.NetworkState {
-	netState := "down"
-	netType := "unknown"
-
-	if netIf.Flags&net.FlagBroadcast > 0 {
-		netType = "broadcast"
+// networkRefreshOverlayFDB rebuilds the VXLAN FDB mesh for an overlay bridge: it appends an FDB
+// entry for every other cluster member's heartbeat address and removes entries for members that
+// have since left the cluster. The VXLAN device and VNI are taken from the network's
+// bridge.overlay.vni config key (bridge.overlay.subnet governs the per-member address range
+// handed out over DHCP, and is consumed by the bridge setup path rather than here).
+func networkRefreshOverlayFDB(n network.Network, heartbeatData *cluster.APIHeartbeat) error {
+	vni := n.Config()["bridge.overlay.vni"]
+	if vni == "" {
+		return fmt.Errorf("bridge.overlay.vni must be set for bridge.mode=overlay")
 	}
 
-	if netIf.Flags&net.FlagPointToPoint > 0 {
-		netType = "point-to-point"
-	}
+	vxlanDev := fmt.Sprintf("vxlan%s", vni)
 
-	if netIf.Flags&net.FlagLoopback > 0 {
-		netType = "loopback"
-	}
+	localAddress := n.LocalAddress()
 
-	if netIf.Flags&net.FlagUp > 0 {
-		netState = "up"
-	}
+	wanted := map[string]bool{}
+	for _, member := range heartbeatData.Members {
+		address, _, err := net.SplitHostPort(member.Address)
+		if err != nil || address == "" || address == localAddress {
+			continue
+		}
 
-	network := api.NetworkState{
-		Addresses: []api.NetworkStateAddress{},
-		Counters:  api.NetworkStateCounters{},
-		Hwaddr:    netIf.HardwareAddr.String(),
-		Mtu:       netIf.MTU,
-		State:     netState,
-		Type:      netType,
+		wanted[address] = true
 	}
 
-	// Populate address information.
-	addrs, err := netIf.Addrs()
+	current := map[string]bool{}
+	out, err := shared.RunCommand("bridge", "fdb", "show", "dev", vxlanDev)
 	if err == nil {
-		for _, addr := range addrs {
-			fields := strings.SplitN(addr.String(), "/", 2)
-			if len(fields) != 2 {
-				continue
-			}
-
-			family := "inet"
-			if strings.Contains(fields[0], ":") {
-				family = "inet6"
-			}
-
-			scope := "global"
-			if strings.HasPrefix(fields[0], "127") {
-				scope = "local"
-			}
-
-			if fields[0] == "::1" {
-				scope = "local"
-			}
-
-			if strings.HasPrefix(fields[0], "169.254") {
-				scope = "link"
+		for _, line := range strings.Split(out, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) >= 3 && fields[0] == "00:00:00:00:00:00" && fields[1] == "dst" {
+				current[fields[2]] = true
 			}
-
-			if strings.HasPrefix(fields[0], "fe80:") {
-				scope = "link"
-			}
-
-			address := api.NetworkStateAddress{}
-			address.Family = family
-			address.Address = fields[0]
-			address.Netmask = fields[1]
-			address.Scope = scope
-
-			network.Addresses = append(network.Addresses, address)
 		}
 	}
 
-	// Populate bond details.
-	bondPath := fmt.Sprintf("/sys/class/net/%s/bonding", netIf.Name)
-	if shared.PathExists(bondPath) {
-		bonding := api.NetworkStateBond{}
-
-		// Bond mode.
-		strValue, err := ioutil.ReadFile(filepath.Join(bondPath, "mode"))
-		if err == nil {
-			bonding.Mode = strings.Split(strings.TrimSpace(string(strValue)), " ")[0]
-		}
-
-		// Bond transmit policy.
-		strValue, err = ioutil.ReadFile(filepath.Join(bondPath, "xmit_hash_policy"))
-		if err == nil {
-			bonding.TransmitPolicy = strings.Split(strings.TrimSpace(string(strValue)), " ")[0]
-		}
-
-		// Up delay.
-		uintValue, err := readUint(filepath.Join(bondPath, "updelay"))
-		if err == nil {
-			bonding.UpDelay = uintValue
-		}
-
-		// Down delay.
-		uintValue, err = readUint(filepath.Join(bondPath, "downdelay"))
-		if err == nil {
-			bonding.DownDelay = uintValue
-		}
-
-		// MII frequency.
-		uintValue, err = readUint(filepath.Join(bondPath, "miimon"))
-		if err == nil {
-			bonding.MIIFrequency = uintValue
-		}
-
-		// MII state.
-		strValue, err = ioutil.ReadFile(filepath.Join(bondPath, "mii_status"))
-		if err == nil {
-			bonding.MIIState = strings.TrimSpace(string(strValue))
+	for address := range wanted {
+		if current[address] {
+			continue
 		}
 
-		// Lower devices.
-		strValue, err = ioutil.ReadFile(filepath.Join(bondPath, "slaves"))
-		if err == nil {
-			bonding.LowerDevices = strings.Split(strings.TrimSpace(string(strValue)), " ")
+		_, err := shared.RunCommand("bridge", "fdb", "append", "00:00:00:00:00:00", "dev", vxlanDev, "dst", address)
+		if err != nil {
+			return err
 		}
-
-		network.Bond = &bonding
 	}
 
-	// Populate bridge details.
-	bridgePath := fmt.Sprintf("/sys/class/net/%s/bridge", netIf.Name)
-	if shared.PathExists(bridgePath) {
-		bridge := api.NetworkStateBridge{}
-
-		// Bridge ID.
-		strValue, err := ioutil.ReadFile(filepath.Join(bridgePath, "bridge_id"))
-		if err == nil {
-			bridge.ID = strings.TrimSpace(string(strValue))
-		}
-
-		// Bridge STP.
-		uintValue, err := readUint(filepath.Join(bridgePath, "stp_state"))
-		if err == nil {
-			bridge.STP = uintValue == 1
-		}
-
-		// Bridge forward delay.
-		uintValue, err = readUint(filepath.Join(bridgePath, "forward_delay"))
-		if err == nil {
-			bridge.ForwardDelay = uintValue
-		}
-
-		// Bridge default VLAN.
-		uintValue, err = readUint(filepath.Join(bridgePath, "default_pvid"))
-		if err == nil {
-			bridge.VLANDefault = uintValue
-		}
-
-		// Bridge VLAN filtering.
-		uintValue, err = readUint(filepath.Join(bridgePath, "vlan_filtering"))
-		if err == nil {
-			bridge.VLANFiltering = uintValue == 1
+	for address := range current {
+		if wanted[address] {
+			continue
 		}
 
-		// Upper devices.
-		bridgeIfPath := fmt.Sprintf("/sys/class/net/%s/brif", netIf.Name)
-		if shared.PathExists(bridgeIfPath) {
-			entries, err := ioutil.ReadDir(bridgeIfPath)
-			if err == nil {
-				bridge.UpperDevices = []string{}
-				for _, entry := range entries {
-					bridge.UpperDevices = append(bridge.UpperDevices, entry.Name())
-				}
-			}
+		_, err := shared.RunCommand("bridge", "fdb", "del", "00:00:00:00:00:00", "dev", vxlanDev, "dst", address)
+		if err != nil {
+			return err
 		}
-
-		network.Bridge = &bridge
 	}
 
-	// Get counters.
-	network.Counters = shared.NetworkGetCounters(netIf.Name)
-	return network
+	return nil
 }
@@ -0,0 +1,63 @@
+package main
+
+import "testing"
+
+func TestNetworkValidAddressCIDRV4(t *testing.T) {
+	cases := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "empty", value: "", wantErr: false},
+		{name: "single subnet", value: "10.0.0.1/24", wantErr: false},
+		{name: "multiple non-overlapping subnets", value: "10.0.0.1/24,10.1.0.1/24", wantErr: false},
+		{name: "multiple subnets with whitespace", value: "10.0.0.1/24, 10.1.0.1/24", wantErr: false},
+		{name: "overlapping subnets", value: "10.0.0.1/24,10.0.0.128/25", wantErr: true},
+		{name: "identical subnets", value: "10.0.0.1/24,10.0.0.2/24", wantErr: true},
+		{name: "ipv6 address rejected", value: "fd00::1/64", wantErr: true},
+		{name: "network address rejected", value: "10.0.0.0/24", wantErr: true},
+		{name: "invalid cidr", value: "not-a-cidr", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := networkValidAddressCIDRV4(c.value)
+			if c.wantErr && err == nil {
+				t.Errorf("networkValidAddressCIDRV4(%q) = nil, want error", c.value)
+			}
+
+			if !c.wantErr && err != nil {
+				t.Errorf("networkValidAddressCIDRV4(%q) = %v, want nil", c.value, err)
+			}
+		})
+	}
+}
+
+func TestNetworkValidAddressCIDRV6(t *testing.T) {
+	cases := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "empty", value: "", wantErr: false},
+		{name: "single subnet", value: "fd00::1/64", wantErr: false},
+		{name: "multiple non-overlapping subnets", value: "fd00::1/64,fd01::1/64", wantErr: false},
+		{name: "overlapping subnets", value: "fd00::1/64,fd00:0:0:0:8000::1/65", wantErr: true},
+		{name: "ipv4 address rejected", value: "10.0.0.1/24", wantErr: true},
+		{name: "network address rejected", value: "fd00::/64", wantErr: true},
+		{name: "invalid cidr", value: "not-a-cidr", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := networkValidAddressCIDRV6(c.value)
+			if c.wantErr && err == nil {
+				t.Errorf("networkValidAddressCIDRV6(%q) = nil, want error", c.value)
+			}
+
+			if !c.wantErr && err != nil {
+				t.Errorf("networkValidAddressCIDRV6(%q) = %v, want nil", c.value, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+// Package network reads host network interface state for use by the daemon and by external
+// consumers (such as MAAS) that want interface state without vendoring the whole daemon.
+package network
+
+import "github.com/lxc/lxd/shared/api"
+
+// stateProvider abstracts the platform-specific mechanism used to read host network interface
+// state and counters. Each supported GOOS provides exactly one implementation, selected at
+// compile time by the build tag on its file and registered into provider from an init().
+type stateProvider interface {
+	State(name string) (*api.NetworkState, error)
+	Counters(name string) (api.NetworkStateCounters, error)
+}
+
+// provider is set by the platform-specific file compiled for the target GOOS.
+var provider stateProvider
+
+// GetNetworkState returns the current state of the named network interface.
+func GetNetworkState(name string) (*api.NetworkState, error) {
+	return provider.State(name)
+}
+
+// GetNetworkCounters returns the current traffic counters for the named network interface.
+func GetNetworkCounters(name string) (api.NetworkStateCounters, error) {
+	return provider.Counters(name)
+}
@@ -0,0 +1,153 @@
+//go:build freebsd
+// +build freebsd
+
+package network
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+type freebsdProvider struct{}
+
+func init() {
+	provider = freebsdProvider{}
+}
+
+// State shells out to ifconfig(8), since FreeBSD exposes interface details via getifaddrs(3)
+// rather than a /sys-style filesystem. Bond membership comes from lagg(4), bridge membership
+// from bridge(4).
+func (p freebsdProvider) State(name string) (*api.NetworkState, error) {
+	out, err := exec.Command("ifconfig", name).CombinedOutput()
+	if err != nil {
+		return nil, err
+	}
+
+	state := parseIfconfigOutput(string(out))
+
+	counters, err := p.Counters(name)
+	if err == nil {
+		state.Counters = counters
+	}
+
+	return state, nil
+}
+
+// parseIfconfigOutput parses the output of `ifconfig <name>` into a NetworkState. Factored out
+// of State so the parsing can be unit tested without shelling out.
+func parseIfconfigOutput(out string) *api.NetworkState {
+	state := &api.NetworkState{
+		Addresses: []api.NetworkStateAddress{},
+		Counters:  api.NetworkStateCounters{},
+		State:     "down",
+		Type:      "unknown",
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+
+		// The interface line looks like "em0: flags=8943<UP,...> metric 0 mtu 1500".
+		if strings.Contains(line, ": flags=") {
+			fields := strings.Fields(line)
+			for i := 0; i+1 < len(fields); i++ {
+				if fields[i] == "mtu" {
+					fmt.Sscanf(fields[i+1], "%d", &state.Mtu)
+				}
+			}
+
+			flags := line
+			if idx := strings.Index(line, "<"); idx >= 0 {
+				if end := strings.Index(line, ">"); end > idx {
+					flags = line[idx+1 : end]
+				}
+			}
+
+			flagList := strings.Split(flags, ",")
+
+			if strings.Contains(flags, "LOOPBACK") {
+				state.Type = "loopback"
+			} else if strings.Contains(flags, "POINTOPOINT") {
+				state.Type = "point-to-point"
+			} else if strings.Contains(flags, "BROADCAST") {
+				state.Type = "broadcast"
+			}
+
+			for _, flag := range flagList {
+				if flag == "UP" {
+					state.State = "up"
+				}
+			}
+		}
+
+		if strings.HasPrefix(line, "ether ") {
+			fields := strings.Fields(line)
+			if len(fields) >= 2 {
+				state.Hwaddr = fields[1]
+			}
+		}
+
+		if strings.HasPrefix(line, "member: ") {
+			if state.Bridge == nil {
+				state.Bridge = &api.NetworkStateBridge{UpperDevices: []string{}}
+			}
+
+			fields := strings.Fields(line)
+			if len(fields) >= 2 {
+				state.Bridge.UpperDevices = append(state.Bridge.UpperDevices, fields[1])
+			}
+		}
+
+		if strings.HasPrefix(line, "laggport: ") {
+			if state.Bond == nil {
+				state.Bond = &api.NetworkStateBond{LowerDevices: []string{}}
+			}
+
+			fields := strings.Fields(line)
+			if len(fields) >= 2 {
+				state.Bond.LowerDevices = append(state.Bond.LowerDevices, fields[1])
+			}
+		}
+	}
+
+	return state
+}
+
+// Counters parses the packets/errs/drops/bytes columns out of `netstat -nbI <iface>`.
+func (freebsdProvider) Counters(name string) (api.NetworkStateCounters, error) {
+	out, err := exec.Command("netstat", "-nbI", name).CombinedOutput()
+	if err != nil {
+		return api.NetworkStateCounters{}, err
+	}
+
+	return parseNetstatCounters(string(out))
+}
+
+// parseNetstatCounters parses the output of `netstat -nbI <iface>` into a NetworkStateCounters.
+// Factored out of Counters so the parsing can be unit tested without shelling out. Column layout
+// (0-indexed) is: Name Mtu Network Address Ipkts Ierrs Idrop Ibytes Opkts Oerrs Obytes Coll.
+func parseNetstatCounters(out string) (api.NetworkStateCounters, error) {
+	counters := api.NetworkStateCounters{}
+
+	lines := strings.Split(out, "\n")
+	if len(lines) < 2 {
+		return counters, fmt.Errorf("Unexpected netstat output")
+	}
+
+	fields := strings.Fields(lines[1])
+	if len(fields) < 11 {
+		return counters, fmt.Errorf("Unexpected netstat output: %q", lines[1])
+	}
+
+	fmt.Sscanf(fields[4], "%d", &counters.PacketsReceived)
+	fmt.Sscanf(fields[5], "%d", &counters.ErrorsReceived)
+	fmt.Sscanf(fields[6], "%d", &counters.DroppedReceived)
+	fmt.Sscanf(fields[7], "%d", &counters.BytesReceived)
+	fmt.Sscanf(fields[8], "%d", &counters.PacketsSent)
+	fmt.Sscanf(fields[9], "%d", &counters.ErrorsSent)
+	fmt.Sscanf(fields[10], "%d", &counters.BytesSent)
+
+	return counters, nil
+}
@@ -0,0 +1,139 @@
+//go:build freebsd
+// +build freebsd
+
+package network
+
+import "testing"
+
+func TestParseIfconfigOutput(t *testing.T) {
+	cases := []struct {
+		name       string
+		out        string
+		wantState  string
+		wantType   string
+		wantHwaddr string
+		wantMtu    int
+	}{
+		{
+			name: "up broadcast interface",
+			out: `em0: flags=8943<UP,BROADCAST,RUNNING,PROMISC,SIMPLEX,MULTICAST> metric 0 mtu 1500
+	options=81009b<RXCSUM,TXCSUM,VLAN_MTU,VLAN_HWTAGGING,VLAN_HWCSUM,LINKSTATE>
+	ether 52:54:00:12:34:56
+	inet 10.0.0.1 netmask 0xffffff00 broadcast 10.0.0.255
+`,
+			wantState:  "up",
+			wantType:   "broadcast",
+			wantHwaddr: "52:54:00:12:34:56",
+			wantMtu:    1500,
+		},
+		{
+			name: "up loopback interface",
+			out: `lo0: flags=8049<UP,LOOPBACK,RUNNING,MULTICAST> metric 0 mtu 16384
+	inet 127.0.0.1 netmask 0xff000000
+`,
+			wantState: "up",
+			wantType:  "loopback",
+			wantMtu:   16384,
+		},
+		{
+			name:      "administratively down interface",
+			out:       "em1: flags=8802<BROADCAST,SIMPLEX,MULTICAST> metric 0 mtu 1500\n",
+			wantState: "down",
+			wantType:  "broadcast",
+			wantMtu:   1500,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			state := parseIfconfigOutput(c.out)
+
+			if state.State != c.wantState {
+				t.Errorf("State = %q, want %q", state.State, c.wantState)
+			}
+
+			if state.Type != c.wantType {
+				t.Errorf("Type = %q, want %q", state.Type, c.wantType)
+			}
+
+			if state.Hwaddr != c.wantHwaddr {
+				t.Errorf("Hwaddr = %q, want %q", state.Hwaddr, c.wantHwaddr)
+			}
+
+			if state.Mtu != c.wantMtu {
+				t.Errorf("Mtu = %d, want %d", state.Mtu, c.wantMtu)
+			}
+		})
+	}
+}
+
+func TestParseIfconfigOutputBridge(t *testing.T) {
+	out := `bridge0: flags=8843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST> metric 0 mtu 1500
+	ether 02:a2:ff:00:01:02
+	member: em0 flags=143<LEARNING,DISCOVER,AUTOEDGE,AUTOPTP>
+	member: em1 flags=143<LEARNING,DISCOVER,AUTOEDGE,AUTOPTP>
+`
+
+	state := parseIfconfigOutput(out)
+
+	if state.Bridge == nil {
+		t.Fatal("expected Bridge to be populated")
+	}
+
+	want := []string{"em0", "em1"}
+	if len(state.Bridge.UpperDevices) != len(want) {
+		t.Fatalf("UpperDevices = %v, want %v", state.Bridge.UpperDevices, want)
+	}
+
+	for i, dev := range want {
+		if state.Bridge.UpperDevices[i] != dev {
+			t.Errorf("UpperDevices[%d] = %q, want %q", i, state.Bridge.UpperDevices[i], dev)
+		}
+	}
+}
+
+func TestParseNetstatCounters(t *testing.T) {
+	out := `Name    Mtu Network       Address              Ipkts Ierrs Idrop     Ibytes Opkts Oerrs     Obytes  Coll
+em0    1500 <Link#1>      52:54:00:12:34:56     1234     1     2     567890   987     3     654321     0
+`
+
+	counters, err := parseNetstatCounters(out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if counters.PacketsReceived != 1234 {
+		t.Errorf("PacketsReceived = %d, want 1234", counters.PacketsReceived)
+	}
+
+	if counters.ErrorsReceived != 1 {
+		t.Errorf("ErrorsReceived = %d, want 1", counters.ErrorsReceived)
+	}
+
+	if counters.DroppedReceived != 2 {
+		t.Errorf("DroppedReceived = %d, want 2", counters.DroppedReceived)
+	}
+
+	if counters.BytesReceived != 567890 {
+		t.Errorf("BytesReceived = %d, want 567890", counters.BytesReceived)
+	}
+
+	if counters.PacketsSent != 987 {
+		t.Errorf("PacketsSent = %d, want 987", counters.PacketsSent)
+	}
+
+	if counters.ErrorsSent != 3 {
+		t.Errorf("ErrorsSent = %d, want 3", counters.ErrorsSent)
+	}
+
+	if counters.BytesSent != 654321 {
+		t.Errorf("BytesSent = %d, want 654321", counters.BytesSent)
+	}
+}
+
+func TestParseNetstatCountersShortOutput(t *testing.T) {
+	_, err := parseNetstatCounters("Name Mtu Network Address\n")
+	if err == nil {
+		t.Fatal("expected an error for truncated netstat output")
+	}
+}
@@ -0,0 +1,332 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+type linuxProvider struct{}
+
+func init() {
+	provider = linuxProvider{}
+}
+
+func readUint(path string) (uint64, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return value, nil
+}
+
+// State reads interface, bond and bridge details from /sys/class/net.
+func (linuxProvider) State(name string) (*api.NetworkState, error) {
+	netIf, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	netState := "down"
+	netType := "unknown"
+
+	if netIf.Flags&net.FlagBroadcast > 0 {
+		netType = "broadcast"
+	}
+
+	if netIf.Flags&net.FlagPointToPoint > 0 {
+		netType = "point-to-point"
+	}
+
+	if netIf.Flags&net.FlagLoopback > 0 {
+		netType = "loopback"
+	}
+
+	if netIf.Flags&net.FlagUp > 0 {
+		netState = "up"
+	}
+
+	state := api.NetworkState{
+		Addresses: []api.NetworkStateAddress{},
+		Counters:  api.NetworkStateCounters{},
+		Hwaddr:    netIf.HardwareAddr.String(),
+		Mtu:       netIf.MTU,
+		State:     netState,
+		Type:      netType,
+	}
+
+	// Populate address information.
+	addrs, err := netIf.Addrs()
+	if err == nil {
+		for _, addr := range addrs {
+			fields := strings.SplitN(addr.String(), "/", 2)
+			if len(fields) != 2 {
+				continue
+			}
+
+			family := "inet"
+			if strings.Contains(fields[0], ":") {
+				family = "inet6"
+			}
+
+			scope := "global"
+			if strings.HasPrefix(fields[0], "127") {
+				scope = "local"
+			}
+
+			if fields[0] == "::1" {
+				scope = "local"
+			}
+
+			if strings.HasPrefix(fields[0], "169.254") {
+				scope = "link"
+			}
+
+			if strings.HasPrefix(fields[0], "fe80:") {
+				scope = "link"
+			}
+
+			address := api.NetworkStateAddress{}
+			address.Family = family
+			address.Address = fields[0]
+			address.Netmask = fields[1]
+			address.Scope = scope
+
+			state.Addresses = append(state.Addresses, address)
+		}
+	}
+
+	// Populate bond details.
+	bondPath := fmt.Sprintf("/sys/class/net/%s/bonding", netIf.Name)
+	if shared.PathExists(bondPath) {
+		bonding := api.NetworkStateBond{}
+
+		// Bond mode.
+		strValue, err := ioutil.ReadFile(filepath.Join(bondPath, "mode"))
+		if err == nil {
+			bonding.Mode = strings.Split(strings.TrimSpace(string(strValue)), " ")[0]
+		}
+
+		// Bond transmit policy.
+		strValue, err = ioutil.ReadFile(filepath.Join(bondPath, "xmit_hash_policy"))
+		if err == nil {
+			bonding.TransmitPolicy = strings.Split(strings.TrimSpace(string(strValue)), " ")[0]
+		}
+
+		// Up delay.
+		uintValue, err := readUint(filepath.Join(bondPath, "updelay"))
+		if err == nil {
+			bonding.UpDelay = uintValue
+		}
+
+		// Down delay.
+		uintValue, err = readUint(filepath.Join(bondPath, "downdelay"))
+		if err == nil {
+			bonding.DownDelay = uintValue
+		}
+
+		// MII frequency.
+		uintValue, err = readUint(filepath.Join(bondPath, "miimon"))
+		if err == nil {
+			bonding.MIIFrequency = uintValue
+		}
+
+		// MII state.
+		strValue, err = ioutil.ReadFile(filepath.Join(bondPath, "mii_status"))
+		if err == nil {
+			bonding.MIIState = strings.TrimSpace(string(strValue))
+		}
+
+		// Lower devices.
+		strValue, err = ioutil.ReadFile(filepath.Join(bondPath, "slaves"))
+		if err == nil {
+			bonding.LowerDevices = strings.Split(strings.TrimSpace(string(strValue)), " ")
+		}
+
+		state.Bond = &bonding
+	}
+
+	// Populate bridge details.
+	bridgePath := fmt.Sprintf("/sys/class/net/%s/bridge", netIf.Name)
+	if shared.PathExists(bridgePath) {
+		bridge := api.NetworkStateBridge{}
+
+		// Bridge ID.
+		strValue, err := ioutil.ReadFile(filepath.Join(bridgePath, "bridge_id"))
+		if err == nil {
+			bridge.ID = strings.TrimSpace(string(strValue))
+		}
+
+		// Bridge STP.
+		uintValue, err := readUint(filepath.Join(bridgePath, "stp_state"))
+		if err == nil {
+			bridge.STP = uintValue == 1
+		}
+
+		// Bridge forward delay.
+		uintValue, err = readUint(filepath.Join(bridgePath, "forward_delay"))
+		if err == nil {
+			bridge.ForwardDelay = uintValue
+		}
+
+		// Bridge default VLAN.
+		uintValue, err = readUint(filepath.Join(bridgePath, "default_pvid"))
+		if err == nil {
+			bridge.VLANDefault = uintValue
+		}
+
+		// Bridge VLAN filtering.
+		uintValue, err = readUint(filepath.Join(bridgePath, "vlan_filtering"))
+		if err == nil {
+			bridge.VLANFiltering = uintValue == 1
+		}
+
+		// Upper devices.
+		bridgeIfPath := fmt.Sprintf("/sys/class/net/%s/brif", netIf.Name)
+		if shared.PathExists(bridgeIfPath) {
+			entries, err := ioutil.ReadDir(bridgeIfPath)
+			if err == nil {
+				bridge.UpperDevices = []string{}
+				for _, entry := range entries {
+					bridge.UpperDevices = append(bridge.UpperDevices, entry.Name())
+				}
+			}
+		}
+
+		state.Bridge = &bridge
+	}
+
+	// Get counters.
+	counters, err := linuxProvider{}.Counters(name)
+	if err == nil {
+		state.Counters = counters
+	}
+
+	// Link speed, in Mbps.
+	speed, err := readUint(fmt.Sprintf("/sys/class/net/%s/speed", name))
+	if err == nil {
+		state.Speed = int(speed)
+	}
+
+	// Duplex mode.
+	strValue, err := ioutil.ReadFile(fmt.Sprintf("/sys/class/net/%s/duplex", name))
+	if err == nil {
+		state.Duplex = strings.TrimSpace(string(strValue))
+	}
+
+	// Physical port type, read via ethtool since there's no plain sysfs attribute for it.
+	portOut, err := exec.Command("ethtool", name).CombinedOutput()
+	if err == nil {
+		for _, line := range strings.Split(string(portOut), "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "Port: ") {
+				state.PortType = strings.TrimPrefix(line, "Port: ")
+			}
+		}
+	}
+
+	// Populate SR-IOV virtual functions, if this is a physical function.
+	devicePath := fmt.Sprintf("/sys/class/net/%s/device", name)
+	entries, err := ioutil.ReadDir(devicePath)
+	if err == nil {
+		vfs := []api.NetworkStateVF{}
+		for _, entry := range entries {
+			if !strings.HasPrefix(entry.Name(), "virtfn") {
+				continue
+			}
+
+			id, err := strconv.Atoi(strings.TrimPrefix(entry.Name(), "virtfn"))
+			if err != nil {
+				continue
+			}
+
+			vf := api.NetworkStateVF{ID: id}
+
+			vfNetPath := filepath.Join(devicePath, entry.Name(), "net")
+			vfIfaces, err := ioutil.ReadDir(vfNetPath)
+			if err == nil && len(vfIfaces) > 0 {
+				mac, err := ioutil.ReadFile(filepath.Join(vfNetPath, vfIfaces[0].Name(), "address"))
+				if err == nil {
+					vf.Hwaddr = strings.TrimSpace(string(mac))
+				}
+			}
+
+			vfs = append(vfs, vf)
+		}
+
+		if len(vfs) > 0 {
+			state.VFs = vfs
+		}
+	}
+
+	return &state, nil
+}
+
+// Counters reads the rx/tx byte, packet, error and dropped-packet counts directly from
+// /sys/class/net/<iface>/statistics, rather than by parsing /proc/net/dev.
+func (linuxProvider) Counters(name string) (api.NetworkStateCounters, error) {
+	counters := api.NetworkStateCounters{}
+
+	statsPath := fmt.Sprintf("/sys/class/net/%s/statistics", name)
+	if !shared.PathExists(statsPath) {
+		return counters, fmt.Errorf("No statistics found for %s", name)
+	}
+
+	rxBytes, err := readUint(filepath.Join(statsPath, "rx_bytes"))
+	if err == nil {
+		counters.BytesReceived = int64(rxBytes)
+	}
+
+	txBytes, err := readUint(filepath.Join(statsPath, "tx_bytes"))
+	if err == nil {
+		counters.BytesSent = int64(txBytes)
+	}
+
+	rxPackets, err := readUint(filepath.Join(statsPath, "rx_packets"))
+	if err == nil {
+		counters.PacketsReceived = int64(rxPackets)
+	}
+
+	txPackets, err := readUint(filepath.Join(statsPath, "tx_packets"))
+	if err == nil {
+		counters.PacketsSent = int64(txPackets)
+	}
+
+	rxErrors, err := readUint(filepath.Join(statsPath, "rx_errors"))
+	if err == nil {
+		counters.ErrorsReceived = int64(rxErrors)
+	}
+
+	txErrors, err := readUint(filepath.Join(statsPath, "tx_errors"))
+	if err == nil {
+		counters.ErrorsSent = int64(txErrors)
+	}
+
+	rxDropped, err := readUint(filepath.Join(statsPath, "rx_dropped"))
+	if err == nil {
+		counters.DroppedReceived = int64(rxDropped)
+	}
+
+	txDropped, err := readUint(filepath.Join(statsPath, "tx_dropped"))
+	if err == nil {
+		counters.DroppedSent = int64(txDropped)
+	}
+
+	return counters, nil
+}
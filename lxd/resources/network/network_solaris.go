@@ -0,0 +1,142 @@
+//go:build solaris
+// +build solaris
+
+package network
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+type solarisProvider struct{}
+
+func init() {
+	provider = solarisProvider{}
+}
+
+// State uses dladm(1M) to read link state and class, and dladm show-aggr/show-bridge for
+// bond/bridge membership.
+func (p solarisProvider) State(name string) (*api.NetworkState, error) {
+	out, err := exec.Command("dladm", "show-link", "-p", "-o", "state,class", name).CombinedOutput()
+	if err != nil {
+		return nil, err
+	}
+
+	state, class, err := parseDladmLinkState(string(out))
+	if err != nil {
+		return nil, err
+	}
+
+	switch class {
+	case "aggr":
+		bondOut, err := exec.Command("dladm", "show-aggr", "-p", "-o", "port", name).CombinedOutput()
+		if err == nil {
+			state.Bond = &api.NetworkStateBond{
+				LowerDevices: strings.Split(strings.TrimSpace(string(bondOut)), ","),
+			}
+		}
+	case "bridge":
+		bridgeOut, err := exec.Command("dladm", "show-bridge", "-p", "-o", "link", name).CombinedOutput()
+		if err == nil {
+			state.Bridge = &api.NetworkStateBridge{
+				UpperDevices: strings.Split(strings.TrimSpace(string(bridgeOut)), ","),
+			}
+		}
+	}
+
+	// Counters returns the stats it could read even when some kstats fail, so keep them.
+	counters, _ := p.Counters(name)
+	state.Counters = counters
+
+	return state, nil
+}
+
+// parseDladmLinkState parses the output of `dladm show-link -p -o state,class <name>` into a
+// NetworkState (with Type derived from class, matching how the Linux/FreeBSD backends derive
+// Type from interface flags) and the raw class string, for the bond/bridge membership lookup.
+// Factored out of State so the parsing can be unit tested without shelling out.
+func parseDladmLinkState(out string) (*api.NetworkState, string, error) {
+	fields := strings.Split(strings.TrimSpace(out), ":")
+	if len(fields) < 2 {
+		return nil, "", fmt.Errorf("Unexpected dladm output: %q", out)
+	}
+
+	state := &api.NetworkState{
+		Addresses: []api.NetworkStateAddress{},
+		Counters:  api.NetworkStateCounters{},
+		State:     "down",
+		Type:      "unknown",
+	}
+
+	if fields[0] == "up" {
+		state.State = "up"
+	}
+
+	class := fields[1]
+	switch class {
+	case "vni":
+		state.Type = "loopback"
+	case "aggr", "bridge", "vlan", "phys":
+		state.Type = "broadcast"
+	}
+
+	return state, class, nil
+}
+
+// Counters reads the rbytes64/obytes64/ipackets64/opackets64/ierrors/oerrors/idrops64/odrops64
+// kstats for the link. Each stat is read independently and best-effort, matching the
+// Linux/FreeBSD backends: a kstat that's missing or fails to parse doesn't discard the stats
+// that were already read successfully. The last error seen, if any, is returned alongside
+// whatever counters were collected.
+func (solarisProvider) Counters(name string) (api.NetworkStateCounters, error) {
+	counters := api.NetworkStateCounters{}
+
+	stats := []struct {
+		kstat string
+		dest  *int64
+	}{
+		{"rbytes64", &counters.BytesReceived},
+		{"obytes64", &counters.BytesSent},
+		{"ipackets64", &counters.PacketsReceived},
+		{"opackets64", &counters.PacketsSent},
+		{"ierrors", &counters.ErrorsReceived},
+		{"oerrors", &counters.ErrorsSent},
+		{"idrops64", &counters.DroppedReceived},
+		{"odrops64", &counters.DroppedSent},
+	}
+
+	var lastErr error
+
+	for _, stat := range stats {
+		out, err := exec.Command("kstat", "-p", "-n", name, "-s", stat.kstat).CombinedOutput()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		value, err := parseKstatValue(string(out))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		*stat.dest = value
+	}
+
+	return counters, lastErr
+}
+
+// parseKstatValue parses the value column out of `kstat -p` output (module:instance:name:stat
+// value). Factored out of Counters so the parsing can be unit tested without shelling out.
+func parseKstatValue(out string) (int64, error) {
+	fields := strings.Fields(out)
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("Unexpected kstat output: %q", out)
+	}
+
+	return strconv.ParseInt(strings.TrimSpace(fields[1]), 10, 64)
+}
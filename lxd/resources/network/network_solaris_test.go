@@ -0,0 +1,68 @@
+//go:build solaris
+// +build solaris
+
+package network
+
+import "testing"
+
+func TestParseDladmLinkState(t *testing.T) {
+	cases := []struct {
+		name      string
+		out       string
+		wantState string
+		wantType  string
+		wantClass string
+		wantErr   bool
+	}{
+		{name: "up physical link", out: "up:phys\n", wantState: "up", wantType: "broadcast", wantClass: "phys"},
+		{name: "down aggr link", out: "down:aggr\n", wantState: "down", wantType: "broadcast", wantClass: "aggr"},
+		{name: "up vni link", out: "up:vni\n", wantState: "up", wantType: "loopback", wantClass: "vni"},
+		{name: "malformed output", out: "garbage", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			state, class, err := parseDladmLinkState(c.out)
+
+			if c.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if state.State != c.wantState {
+				t.Errorf("State = %q, want %q", state.State, c.wantState)
+			}
+
+			if state.Type != c.wantType {
+				t.Errorf("Type = %q, want %q", state.Type, c.wantType)
+			}
+
+			if class != c.wantClass {
+				t.Errorf("class = %q, want %q", class, c.wantClass)
+			}
+		})
+	}
+}
+
+func TestParseKstatValue(t *testing.T) {
+	value, err := parseKstatValue("net:0:e1000g0:rbytes64    1234567\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if value != 1234567 {
+		t.Errorf("value = %d, want 1234567", value)
+	}
+
+	_, err = parseKstatValue("")
+	if err == nil {
+		t.Fatal("expected an error for empty output")
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+// NetworkStateAddress represents an address assigned to a network interface.
+type NetworkStateAddress struct {
+	Family  string `json:"family" yaml:"family"`
+	Address string `json:"address" yaml:"address"`
+	Netmask string `json:"netmask" yaml:"netmask"`
+	Scope   string `json:"scope" yaml:"scope"`
+}
+
+// NetworkStateCounters represents the traffic counters for a network interface.
+type NetworkStateCounters struct {
+	BytesReceived   int64 `json:"bytes_received" yaml:"bytes_received"`
+	BytesSent       int64 `json:"bytes_sent" yaml:"bytes_sent"`
+	PacketsReceived int64 `json:"packets_received" yaml:"packets_received"`
+	PacketsSent     int64 `json:"packets_sent" yaml:"packets_sent"`
+	ErrorsReceived  int64 `json:"errors_received" yaml:"errors_received"`
+	ErrorsSent      int64 `json:"errors_sent" yaml:"errors_sent"`
+	DroppedReceived int64 `json:"dropped_received" yaml:"dropped_received"`
+	DroppedSent     int64 `json:"dropped_sent" yaml:"dropped_sent"`
+}
+
+// NetworkStateBond represents bond settings for a network interface.
+type NetworkStateBond struct {
+	Mode           string   `json:"mode" yaml:"mode"`
+	TransmitPolicy string   `json:"transmit_policy" yaml:"transmit_policy"`
+	UpDelay        uint64   `json:"up_delay" yaml:"up_delay"`
+	DownDelay      uint64   `json:"down_delay" yaml:"down_delay"`
+	MIIFrequency   uint64   `json:"mii_frequency" yaml:"mii_frequency"`
+	MIIState       string   `json:"mii_state" yaml:"mii_state"`
+	LowerDevices   []string `json:"lower_devices" yaml:"lower_devices"`
+}
+
+// NetworkStateBridge represents bridge settings for a network interface.
+type NetworkStateBridge struct {
+	ID            string   `json:"id" yaml:"id"`
+	STP           bool     `json:"stp" yaml:"stp"`
+	ForwardDelay  uint64   `json:"forward_delay" yaml:"forward_delay"`
+	VLANDefault   uint64   `json:"vlan_default" yaml:"vlan_default"`
+	VLANFiltering bool     `json:"vlan_filtering" yaml:"vlan_filtering"`
+	UpperDevices  []string `json:"upper_devices" yaml:"upper_devices"`
+}
+
+// NetworkStateVF represents one SR-IOV virtual function of a physical NIC.
+type NetworkStateVF struct {
+	ID     int    `json:"id" yaml:"id"`
+	Hwaddr string `json:"hwaddr" yaml:"hwaddr"`
+}
+
+// NetworkState represents the current state of a network interface.
+type NetworkState struct {
+	Addresses []NetworkStateAddress `json:"addresses" yaml:"addresses"`
+	Counters  NetworkStateCounters  `json:"counters" yaml:"counters"`
+	Hwaddr    string                `json:"hwaddr" yaml:"hwaddr"`
+	Mtu       int                   `json:"mtu" yaml:"mtu"`
+	State     string                `json:"state" yaml:"state"`
+	Type      string                `json:"type" yaml:"type"`
+	Bond      *NetworkStateBond     `json:"bond" yaml:"bond"`
+	Bridge    *NetworkStateBridge   `json:"bridge" yaml:"bridge"`
+
+	// Speed is the link speed in Mbps, Duplex is "half" or "full", and PortType is the
+	// physical port type (e.g. "Twisted Pair", "Fibre") as reported by the NIC driver. All
+	// three are best-effort: many virtual interfaces (bridges, veths, ...) don't expose them.
+	Speed    int    `json:"speed" yaml:"speed"`
+	Duplex   string `json:"duplex" yaml:"duplex"`
+	PortType string `json:"port_type" yaml:"port_type"`
+
+	// VFs lists the SR-IOV virtual functions of this interface, if it is a physical function.
+	VFs []NetworkStateVF `json:"vfs" yaml:"vfs"`
+}